@@ -0,0 +1,122 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+)
+
+// blobHandle is the driver.Value Rows.Next substitutes for a BLOB column
+// that arrived as a blobRef rather than decoded bytes (see blobRef), binding
+// it to the session the row came from so *BlobReader can fetch the value's
+// chunks on demand via session.fetchBlobChunk.
+//
+// Because database/sql decides how to convert a column's value only after
+// Rows.Next has already returned it, a *blobHandle can only be consumed by
+// a destination that implements sql.Scanner (like *BlobReader below);
+// *sql.RawBytes has no such hook; database/sql's own conversion table only
+// recognizes a string or []byte source for it. So scanning a blobHandle
+// column into *sql.RawBytes returns database/sql's "unsupported Scan"
+// error rather than a value — see TestRawBytesScan for the boundary this
+// leaves: RawBytes gets its zero-copy view for an inlined (small) BLOB
+// column; an oversized one must be scanned into *BlobReader instead.
+type blobHandle struct {
+	session *session
+	blobID  uint64
+}
+
+// BlobReader presents a BLOB column as a stream instead of forcing the
+// whole column into a single []byte allocation. Scan a *BlobReader the way
+// you would a *sql.RawBytes; BlobReader itself implements io.Reader. A
+// column small enough to have been inlined as []byte is served from that
+// buffer directly; an oversized column is backed by a blobHandle instead,
+// and Read pulls each chunk from the Transaction Engine as it is consumed.
+// Only *BlobReader can consume an oversized column this way — see
+// blobHandle's doc comment for why *sql.RawBytes cannot.
+type BlobReader struct {
+	r      *bytes.Reader
+	handle *blobHandle
+	offset int64
+	eof    bool
+}
+
+func (b *BlobReader) Read(p []byte) (int, error) {
+	if b.handle != nil {
+		return b.readChunk(p)
+	}
+	if b.r == nil {
+		return 0, io.EOF
+	}
+	return b.r.Read(p)
+}
+
+func (b *BlobReader) readChunk(p []byte) (int, error) {
+	if b.eof || len(p) == 0 {
+		if b.eof {
+			return 0, io.EOF
+		}
+		return 0, nil
+	}
+	data, eof, err := b.handle.session.fetchBlobChunk(context.Background(), b.handle.blobID, b.offset, len(p))
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, data)
+	b.offset += int64(n)
+	b.eof = eof
+	if n == 0 && eof {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Scan implements sql.Scanner.
+func (b *BlobReader) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		b.r, b.handle = nil, nil
+	case []byte:
+		b.r, b.handle = bytes.NewReader(v), nil
+	case *blobHandle:
+		b.r = nil
+		b.handle = v
+		b.offset, b.eof = 0, false
+	default:
+		return fmt.Errorf("nuodb: cannot scan %T into *BlobReader", src)
+	}
+	return nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker. An io.Reader bind
+// argument is streamed to the server in chunks via the session (see
+// session.uploadBlob) rather than read into memory up front, so that
+// INSERT ... VALUES (?) can upload a large BLOB without the caller or the
+// driver ever holding the whole payload at once. Every other value falls
+// back to the default driver conversion rules.
+func (c *Conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if r, ok := nv.Value.(io.Reader); ok {
+		ref, err := c.getSession().uploadBlob(context.Background(), r)
+		if err != nil {
+			return err
+		}
+		nv.Value = ref
+		return nil
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = v
+	return nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker by delegating to the
+// owning connection, so Stmt.Exec/Query accept the same argument types as
+// Conn.Exec/Query.
+func (s *Stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.conn.CheckNamedValue(nv)
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"testing"
+)
+
+// TestBlobStreaming exercises a multi-MB BLOB round-trip: uploading via an
+// io.Reader bind argument, and reading back through a *BlobReader instead
+// of a []byte.
+func TestBlobStreaming(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarEleven (id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL, blob1 BLOB)")
+
+	payload := bytes.Repeat([]byte("nuodb-blob-streaming-"), 200000) // ~4.2MB
+
+	exec(t, db, "INSERT INTO tests.FooBarEleven (blob1) VALUES (?)", bytes.NewReader(payload))
+
+	rows := query(t, db, "SELECT blob1 FROM tests.FooBarEleven")
+	if !rows.Next() {
+		t.Fatal("Should have had rows", rows)
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+
+	var reader BlobReader
+	if err := rows.Scan(&reader); err != nil {
+		t.Fatal("Failed to scan:", err)
+	}
+	got, err := io.ReadAll(&reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("Blob round-trip mismatch: got %d bytes, expected %d", len(got), len(payload))
+	}
+}
+
+// TestRawBytesScan exercises scanning a small BLOB column, one that arrives
+// already decoded rather than as a streaming blobHandle, into *sql.RawBytes
+// for a zero-copy view of the row's buffer.
+func TestRawBytesScan(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarTwelve (id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL, blob1 BLOB)")
+
+	payload := []byte("nuodb-rawbytes")
+	exec(t, db, "INSERT INTO tests.FooBarTwelve (blob1) VALUES (?)", payload)
+
+	rows := query(t, db, "SELECT blob1 FROM tests.FooBarTwelve")
+	if !rows.Next() {
+		t.Fatal("Should have had rows", rows)
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+
+	var raw sql.RawBytes
+	if err := rows.Scan(&raw); err != nil {
+		t.Fatal("Failed to scan:", err)
+	}
+	if !bytes.Equal(raw, payload) {
+		t.Fatalf("RawBytes mismatch: got %v, expected %v", raw, payload)
+	}
+}
+
+// TestRawBytesScanOversizedBlob documents the boundary described on
+// blobHandle: an oversized BLOB column arrives as a streaming blobHandle,
+// which only a Scanner destination like *BlobReader can consume, so scanning
+// it into *sql.RawBytes must fail rather than silently hand back a value
+// database/sql never actually decoded.
+func TestRawBytesScanOversizedBlob(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarFourteen (id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL, blob1 BLOB)")
+
+	payload := bytes.Repeat([]byte("nuodb-blob-streaming-"), 200000) // ~4.2MB
+	exec(t, db, "INSERT INTO tests.FooBarFourteen (blob1) VALUES (?)", bytes.NewReader(payload))
+
+	rows := query(t, db, "SELECT blob1 FROM tests.FooBarFourteen")
+	if !rows.Next() {
+		t.Fatal("Should have had rows", rows)
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+
+	var raw sql.RawBytes
+	if err := rows.Scan(&raw); err == nil {
+		t.Fatal("Expected scanning an oversized BLOB into *sql.RawBytes to fail")
+	}
+	rows.Close()
+
+	rows = query(t, db, "SELECT blob1 FROM tests.FooBarFourteen")
+	if !rows.Next() {
+		t.Fatal("Should have had rows", rows)
+	}
+	var reader BlobReader
+	if err := rows.Scan(&reader); err != nil {
+		t.Fatal("Expected the same column to scan into *BlobReader:", err)
+	}
+	got, err := io.ReadAll(&reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("Blob round-trip mismatch: got %d bytes, expected %d", len(got), len(payload))
+	}
+}
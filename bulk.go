@@ -0,0 +1,136 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// bulkInsertBatchSize bounds how many rows accumulate before BulkInserter
+// flushes them as a single multi-row INSERT.
+const bulkInsertBatchSize = 500
+
+// BulkInserter batches rows bound for one table into multi-row
+// "INSERT ... VALUES (?,...),(?,...),..." statements, amortizing the
+// network round-trip that a prepared statement still pays per row under
+// insert-heavy workloads.
+type BulkInserter struct {
+	tx      *sql.Tx
+	table   string
+	columns []string
+
+	stmt     *sql.Stmt
+	stmtRows int
+	buffered []interface{}
+	closed   bool
+}
+
+// BulkInsert returns a BulkInserter that accumulates rows for table within
+// tx, flushing them to the server in batches of up to bulkInsertBatchSize
+// rows. tx must still be committed or rolled back by the caller; Close only
+// flushes whatever rows are left buffered.
+func BulkInsert(tx *sql.Tx, table string, columns ...string) (*BulkInserter, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("nuodb: BulkInsert requires at least one column")
+	}
+	return &BulkInserter{tx: tx, table: table, columns: columns}, nil
+}
+
+// AddRow buffers one row of column values, flushing the pending batch to
+// the server once it reaches bulkInsertBatchSize rows.
+func (b *BulkInserter) AddRow(values ...interface{}) error {
+	if b.closed {
+		return fmt.Errorf("nuodb: AddRow called on a closed BulkInserter")
+	}
+	if len(values) != len(b.columns) {
+		return fmt.Errorf("nuodb: BulkInsert has %d columns, got %d values", len(b.columns), len(values))
+	}
+	b.buffered = append(b.buffered, values...)
+	if len(b.buffered)/len(b.columns) >= bulkInsertBatchSize {
+		return b.flush()
+	}
+	return nil
+}
+
+// Close flushes any rows still buffered and releases the prepared
+// statement. It does not commit or roll back the surrounding transaction.
+func (b *BulkInserter) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if err := b.flush(); err != nil {
+		return err
+	}
+	if b.stmt != nil {
+		return b.stmt.Close()
+	}
+	return nil
+}
+
+// flush executes the currently buffered rows as a single multi-row INSERT,
+// re-preparing the statement only when the batch size differs from the one
+// the cached statement was bound for (i.e. the first flush, and the final,
+// partial one at Close).
+func (b *BulkInserter) flush() error {
+	rows := len(b.buffered) / len(b.columns)
+	if rows == 0 {
+		return nil
+	}
+	if b.stmt == nil || b.stmtRows != rows {
+		if b.stmt != nil {
+			b.stmt.Close()
+		}
+		stmt, err := b.tx.Prepare(bulkInsertSQL(b.table, b.columns, rows))
+		if err != nil {
+			return err
+		}
+		b.stmt = stmt
+		b.stmtRows = rows
+	}
+	if _, err := b.stmt.Exec(b.buffered...); err != nil {
+		return err
+	}
+	b.buffered = b.buffered[:0]
+	return nil
+}
+
+func bulkInsertSQL(table string, columns []string, rows int) string {
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	values := make([]string, rows)
+	for i := range values {
+		values[i] = placeholder
+	}
+
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(table)
+	sb.WriteString(" (")
+	sb.WriteString(strings.Join(columns, ", "))
+	sb.WriteString(") VALUES ")
+	sb.WriteString(strings.Join(values, ","))
+	return sb.String()
+}
+
+// BulkInsertRows is the driver-level primitive behind BulkInserter: it binds
+// a single multi-row INSERT built from rows and executes it directly on c,
+// for callers that already hold a *Conn (e.g. via a Connector) rather than
+// going through database/sql.
+func (c *Conn) BulkInsertRows(ctx context.Context, table string, columns []string, rows [][]driver.Value) (driver.Result, error) {
+	if len(rows) == 0 {
+		return &Result{}, nil
+	}
+	query := bulkInsertSQL(table, columns, len(rows))
+	args := make([]driver.Value, 0, len(rows)*len(columns))
+	for _, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("nuodb: BulkInsertRows has %d columns, row has %d values", len(columns), len(row))
+		}
+		args = append(args, row...)
+	}
+	return c.exec(ctx, query, namedValuesFromValues(args))
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestBulkInsert loads 10,000 rows through BulkInsert and compares both the
+// resulting row count and the elapsed time against a per-row INSERT baseline
+// for the same data.
+func TestBulkInsert(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarNine (id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL, "+
+		"payload BLOB, label STRING)")
+	exec(t, db, "CREATE TABLE tests.FooBarNinePerRow (id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL, "+
+		"payload BLOB, label STRING)")
+
+	const rowCount = 10000
+
+	bulkStart := time.Now()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inserter, err := BulkInsert(tx, "tests.FooBarNine", "payload", "label")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < rowCount; i++ {
+		if err := inserter.AddRow([]byte{byte(i), byte(i >> 8)}, fmt.Sprintf("row-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := inserter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	bulkElapsed := time.Since(bulkStart)
+
+	var count int64
+	rows := query(t, db, "SELECT COUNT(*) FROM tests.FooBarNine")
+	if !rows.Next() {
+		t.Fatal("Expected a row")
+	}
+	if err := rows.Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != rowCount {
+		t.Fatalf("Expected %d rows, got %d", rowCount, count)
+	}
+
+	perRowStart := time.Now()
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, err := tx.Prepare("INSERT INTO tests.FooBarNinePerRow (payload, label) VALUES (?, ?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < rowCount; i++ {
+		if _, err := stmt.Exec([]byte{byte(i), byte(i >> 8)}, fmt.Sprintf("row-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stmt.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	perRowElapsed := time.Since(perRowStart)
+
+	t.Logf("BulkInsert: %s for %d rows, per-row INSERT: %s", bulkElapsed, rowCount, perRowElapsed)
+	if bulkElapsed >= perRowElapsed {
+		t.Fatalf("Expected BulkInsert (%s) to be faster than per-row INSERT (%s) for %d rows",
+			bulkElapsed, perRowElapsed, rowCount)
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+)
+
+// Conn represents a single connection to a NuoDB database. It implements
+// driver.Conn plus the Go 1.8 context-aware extensions: driver.ConnBeginTx,
+// driver.ExecerContext, driver.QueryerContext and driver.Pinger.
+type Conn struct {
+	cfg     *Config
+	session *session
+
+	mu     sync.Mutex // guards closed, inTx and session (across a reconnect)
+	closed bool
+	inTx   bool
+
+	stmtsMu sync.Mutex
+	stmts   map[*Stmt]string // live prepared statements, re-prepared after a reconnect
+}
+
+func dial(ctx context.Context, cfg *Config) (*Conn, error) {
+	sess, err := newSession(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{cfg: cfg, session: sess, stmts: make(map[*Stmt]string)}, nil
+}
+
+func (c *Conn) Prepare(query string) (driver.Stmt, error) {
+	return c.prepare(context.Background(), query)
+}
+
+func (c *Conn) prepare(ctx context.Context, query string) (stmt *Stmt, err error) {
+	stop := c.watch(ctx)
+	defer stop()
+	err = c.withReconnect(ctx, func() error {
+		handle, numInput, err := c.getSession().prepare(ctx, query)
+		if err != nil {
+			return err
+		}
+		stmt = &Stmt{conn: c, handle: handle, numInput: numInput}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.stmtsMu.Lock()
+	c.stmts[stmt] = query
+	c.stmtsMu.Unlock()
+	return stmt, nil
+}
+
+// getSession returns the connection's current session under c.mu, so a
+// reconnect swapping c.session never races with a concurrent read of it (for
+// example from the watchdog goroutine started by watch).
+func (c *Conn) getSession() *session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
+}
+
+func (c *Conn) forgetStmt(stmt *Stmt) {
+	c.stmtsMu.Lock()
+	delete(c.stmts, stmt)
+	c.stmtsMu.Unlock()
+}
+
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.session.close()
+}
+
+func (c *Conn) Begin() (driver.Tx, error) {
+	return c.begin(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx so that sql.DB.BeginTx can request a
+// non-default isolation level, mapped onto NuoDB's
+// "SET TRANSACTION ISOLATION LEVEL ..." syntax, and a read-only access mode.
+func (c *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.begin(ctx, opts)
+}
+
+func (c *Conn) begin(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	stop := c.watch(ctx)
+	defer stop()
+
+	level, err := isolationFromGoSQL(opts.Isolation)
+	if err != nil {
+		return nil, err
+	}
+	err = c.withReconnect(ctx, func() error {
+		sess := c.getSession()
+		if stmt := isolationStatement(level, opts.ReadOnly); stmt != "" {
+			if _, err := sess.executeDirect(ctx, stmt, nil); err != nil {
+				return err
+			}
+		}
+		return sess.begin(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.inTx = true
+	c.mu.Unlock()
+	return &Tx{conn: c}, nil
+}
+
+func (c *Conn) endTx() {
+	c.mu.Lock()
+	c.inTx = false
+	c.mu.Unlock()
+}
+
+func (c *Conn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	return c.exec(context.Background(), query, namedValuesFromValues(args))
+}
+
+// ExecContext implements driver.ExecerContext, propagating ctx cancellation
+// to the underlying NuoDB session via a watchdog goroutine.
+func (c *Conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.exec(ctx, query, args)
+}
+
+func (c *Conn) exec(ctx context.Context, query string, args []driver.NamedValue) (result driver.Result, err error) {
+	stop := c.watch(ctx)
+	defer stop()
+	err = c.withReconnect(ctx, func() error {
+		var err error
+		result, err = c.getSession().executeDirect(ctx, query, args)
+		return err
+	})
+	return result, err
+}
+
+func (c *Conn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	return c.query(context.Background(), query, namedValuesFromValues(args))
+}
+
+// QueryContext implements driver.QueryerContext, propagating ctx
+// cancellation to the underlying NuoDB session via a watchdog goroutine.
+func (c *Conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.query(ctx, query, args)
+}
+
+func (c *Conn) query(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	stop := c.watch(ctx)
+	defer stop()
+	err = c.withReconnect(ctx, func() error {
+		r, err := c.getSession().queryDirect(ctx, query, args)
+		if err != nil {
+			return err
+		}
+		rows = r
+		return nil
+	})
+	return rows, err
+}
+
+// Ping implements driver.Pinger.
+func (c *Conn) Ping(ctx context.Context) error {
+	stop := c.watch(ctx)
+	defer stop()
+	return c.withReconnect(ctx, func() error {
+		return c.getSession().ping(ctx)
+	})
+}
+
+// watch spawns a goroutine that cancels the statement in flight on this
+// connection's session when ctx is done, so a long-running SELECT aborts
+// promptly instead of waiting for the server to finish on its own. The
+// returned stop function must be called once the operation has completed to
+// avoid leaking the goroutine.
+//
+// A session is long-lived across every call made on a pooled *Conn, so
+// resetCancel re-arms it for this call before the watchdog is started;
+// without that, a session that already cancelled one operation would treat
+// every later cancellation on the same connection as a no-op.
+func (c *Conn) watch(ctx context.Context) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	c.getSession().resetCancel()
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.getSession().cancel()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// withReconnect runs fn, and if it fails with a NuoDB connection error
+// (code -10) reported outside a transaction, transparently dials another
+// broker, re-prepares every statement still tracked in c.stmts against the
+// new session, and retries fn once. Inside a transaction, or if the
+// reconnect attempt itself fails, there is no server-side state to recover,
+// so it returns driver.ErrBadConn instead, telling database/sql to discard
+// this Conn and retry on a fresh one.
+func (c *Conn) withReconnect(ctx context.Context, fn func() error) error {
+	err := fn()
+	if !isConnectionError(err) {
+		return err
+	}
+
+	c.mu.Lock()
+	inTx := c.inTx
+	c.mu.Unlock()
+	if inTx {
+		return driver.ErrBadConn
+	}
+
+	if reconnectErr := c.reconnect(ctx); reconnectErr != nil {
+		return driver.ErrBadConn
+	}
+	return fn()
+}
+
+// preparedHandle is the result of re-preparing a single statement against a
+// new session during reconnect.
+type preparedHandle struct {
+	handle   uint64
+	numInput int
+}
+
+func (c *Conn) reconnect(ctx context.Context) error {
+	sess, err := newSession(ctx, c.cfg)
+	if err != nil {
+		return err
+	}
+
+	c.stmtsMu.Lock()
+	defer c.stmtsMu.Unlock()
+
+	// Re-prepare every statement against the new session before mutating
+	// anything. If the batch fails partway through, c.stmts must be left
+	// pointing at the old (already-closed) session rather than a mix of
+	// old and new handles.
+	prepared := make(map[*Stmt]preparedHandle, len(c.stmts))
+	for stmt, query := range c.stmts {
+		handle, numInput, err := sess.prepare(ctx, query)
+		if err != nil {
+			sess.close()
+			return err
+		}
+		prepared[stmt] = preparedHandle{handle: handle, numInput: numInput}
+	}
+
+	for stmt, p := range prepared {
+		stmt.handle = p.handle
+		stmt.numInput = p.numInput
+	}
+
+	c.mu.Lock()
+	c.session = sess
+	c.mu.Unlock()
+	return nil
+}
+
+func isConnectionError(err error) bool {
+	nerr, ok := err.(*Error)
+	return ok && nerr.Code == errConnection
+}
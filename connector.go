@@ -0,0 +1,114 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// DialFunc dials the raw network connection to a NuoDB Transaction Engine.
+// Set Config.DialFunc to one to route connections through a custom proxy or
+// service-mesh sidecar instead of a plain net.Dialer.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Config holds typed connection parameters, as an alternative to building
+// and escaping a "nuodb://" DSN string by hand. Use NewConnector and
+// sql.OpenDB to open connections built from a Config.
+type Config struct {
+	User       string
+	Password   string
+	Host       string
+	Port       string
+	Database   string
+	Schema     string
+	Timezone   string
+	ClientInfo string
+	Properties map[string]string
+
+	// Isolation is the NuoDB "SET TRANSACTION ISOLATION LEVEL" keyword
+	// (e.g. "CONSISTENT READ") applied to every transaction opened on the
+	// connection. Empty leaves the server default in place.
+	Isolation string
+	// ReadOnly applies "READ ONLY" access mode to every transaction
+	// opened on the connection.
+	ReadOnly bool
+
+	// DialTimeout bounds how long dialing a single broker may take. Zero
+	// means no timeout beyond whatever Context is passed to Connect.
+	DialTimeout time.Duration
+	// DialFunc, if set, replaces the default net.Dialer.
+	DialFunc DialFunc
+	// TLSConfig, if set, wraps the dialed connection in a TLS client
+	// connection for encrypted transport.
+	TLSConfig *tls.Config
+
+	// Brokers, if set, lists additional "host:port" NuoDB brokers to try
+	// in case Host:Port is unreachable, enabling failover across a
+	// multi-broker deployment. Host/Port is always tried first.
+	Brokers []string
+	// FailoverMode selects how Brokers are tried after Host/Port:
+	// "roundrobin" (the default) tries them in listed order, "random"
+	// shuffles them first.
+	FailoverMode string
+	// MaxRetries bounds how many brokers (Host/Port plus Brokers) are
+	// tried before Open or a post-failure reconnect gives up. Zero means
+	// try all of them once.
+	MaxRetries int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Port == "" {
+		c.Port = defaultPort
+	}
+	return c
+}
+
+// brokerAddrs returns every broker this Config should try to dial, in the
+// order failover should attempt them: Host:Port first, then Brokers in
+// FailoverMode order.
+func (c *Config) brokerAddrs() []string {
+	addrs := make([]string, 0, 1+len(c.Brokers))
+	if c.Host != "" {
+		addrs = append(addrs, net.JoinHostPort(c.Host, c.Port))
+	}
+	rest := append([]string(nil), c.Brokers...)
+	if c.FailoverMode == "random" {
+		rand.Shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] })
+	}
+	return append(addrs, rest...)
+}
+
+// Connector implements database/sql/driver.Connector, letting callers open
+// connections built from a typed Config — including a custom *tls.Config or
+// DialFunc for injecting credential rotation or a proxied dialer — without
+// parsing and escaping a DSN string.
+type Connector struct {
+	cfg Config
+}
+
+// NewConnector builds a Connector from cfg for use with sql.OpenDB(connector).
+func NewConnector(cfg Config) (*Connector, error) {
+	if cfg.Host == "" && len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("nuodb: Config.Host or Config.Brokers is required")
+	}
+	if cfg.Database == "" {
+		return nil, fmt.Errorf("nuodb: Config.Database is required")
+	}
+	return &Connector{cfg: cfg.withDefaults()}, nil
+}
+
+// Connect implements driver.Connector.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return dial(ctx, &c.cfg)
+}
+
+// Driver implements driver.Connector.
+func (c *Connector) Driver() driver.Driver {
+	return &Driver{}
+}
@@ -0,0 +1,34 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNewConnectorRequiresHostAndDatabase(t *testing.T) {
+	if _, err := NewConnector(Config{Database: "tests"}); err == nil {
+		t.Fatal("Expected error for missing Host")
+	}
+	if _, err := NewConnector(Config{Host: "localhost"}); err == nil {
+		t.Fatal("Expected error for missing Database")
+	}
+}
+
+func TestOpenDBWithConnector(t *testing.T) {
+	connector, err := NewConnector(Config{
+		User:     "robinh",
+		Password: "crossbow",
+		Host:     "localhost",
+		Database: "tests",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	exec(t, db, "DROP SCHEMA CASCADE IF EXISTS tests")
+	exec(t, db, "CREATE SCHEMA tests")
+}
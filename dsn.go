@@ -0,0 +1,155 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPort = "48004"
+
+// parseDSN parses a "nuodb://" data source name into a Config. The host
+// portion may be a comma-separated list of brokers for failover, following
+// the style of PostgreSQL's comma-separated "host=" list, e.g.
+//
+//	nuodb://user:pw@broker1:48004,broker2:48004/database
+//	    ?schema=foo&timezone=UTC&isolation=serializable&readonly=true
+//	    &failoverMode=roundrobin&connectTimeout=5s&maxRetries=3
+//
+// It covers everything a DSN can express; TLSConfig and DialFunc have no
+// DSN equivalent and are only settable via Config directly, through
+// NewConnector.
+func parseDSN(dsn string) (*Config, error) {
+	authority, remainder, err := splitDSNAuthority(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	userinfo, hostList := authority, authority
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		userinfo, hostList = authority[:i], authority[i+1:]
+	} else {
+		userinfo = ""
+	}
+
+	brokers, err := parseBrokerList(hostList)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rebuild a single-host DSN so net/url can do the rest of the parsing
+	// (userinfo unescaping, path, query) the way it always has.
+	single := "nuodb://"
+	if userinfo != "" {
+		single += userinfo + "@"
+	}
+	single += net.JoinHostPort(brokers[0].host, brokers[0].port) + remainder
+
+	u, err := url.Parse(single)
+	if err != nil {
+		return nil, fmt.Errorf("nuodb: invalid dsn: %s", err)
+	}
+
+	cfg := &Config{
+		Host:     brokers[0].host,
+		Port:     brokers[0].port,
+		Database: strings.TrimPrefix(u.Path, "/"),
+	}
+	for _, b := range brokers[1:] {
+		cfg.Brokers = append(cfg.Brokers, net.JoinHostPort(b.host, b.port))
+	}
+	if u.User != nil {
+		cfg.User = u.User.Username()
+		cfg.Password, _ = u.User.Password()
+	}
+
+	for k, v := range u.Query() {
+		if len(v) == 0 {
+			continue
+		}
+		switch k {
+		case "schema":
+			cfg.Schema = v[0]
+		case "timezone":
+			cfg.Timezone = v[0]
+		case "clientInfo":
+			cfg.ClientInfo = v[0]
+		case "isolation":
+			level, err := isolationFromDSN(v[0])
+			if err != nil {
+				return nil, err
+			}
+			cfg.Isolation = level
+		case "readonly":
+			readOnly, err := strconv.ParseBool(v[0])
+			if err != nil {
+				return nil, fmt.Errorf("nuodb: invalid readonly value %q in dsn", v[0])
+			}
+			cfg.ReadOnly = readOnly
+		case "failoverMode":
+			cfg.FailoverMode = v[0]
+		case "connectTimeout":
+			d, err := time.ParseDuration(v[0])
+			if err != nil {
+				return nil, fmt.Errorf("nuodb: invalid connectTimeout %q in dsn", v[0])
+			}
+			cfg.DialTimeout = d
+		case "maxRetries":
+			n, err := strconv.Atoi(v[0])
+			if err != nil {
+				return nil, fmt.Errorf("nuodb: invalid maxRetries %q in dsn", v[0])
+			}
+			cfg.MaxRetries = n
+		}
+	}
+	return cfg, nil
+}
+
+// splitDSNAuthority strips the "nuodb://" scheme and splits what remains
+// into the authority (userinfo and host list) and everything from the
+// first "/" or "?" onward. It exists because the host list may contain
+// commas and multiple colons, which net/url's own host parsing rejects.
+func splitDSNAuthority(dsn string) (authority, remainder string, err error) {
+	const scheme = "nuodb://"
+	if !strings.HasPrefix(dsn, scheme) {
+		return "", "", fmt.Errorf("nuodb: invalid dsn, expected it to start with %q", scheme)
+	}
+	rest := dsn[len(scheme):]
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		return rest[:i], rest[i:], nil
+	}
+	return rest, "", nil
+}
+
+type brokerAddr struct {
+	host, port string
+}
+
+// parseBrokerList splits a comma-separated "host:port,host:port,..." list,
+// defaulting the port on any entry that omits one.
+func parseBrokerList(hostList string) ([]brokerAddr, error) {
+	var brokers []brokerAddr
+	for _, entry := range strings.Split(hostList, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(entry)
+		if err != nil {
+			host, port = entry, defaultPort
+		}
+		if port == "" {
+			port = defaultPort
+		}
+		brokers = append(brokers, brokerAddr{host: host, port: port})
+	}
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("nuodb: dsn has no broker host")
+	}
+	return brokers, nil
+}
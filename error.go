@@ -0,0 +1,38 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode identifies the class of error a NuoDB Transaction Engine
+// reported in a SQLException response (e.g. -10 for a connection error,
+// -25 for a missing table).
+type ErrorCode int
+
+const (
+	errConnection ErrorCode = -10
+)
+
+// Error is returned for any error reported by a NuoDB server, and also for a
+// failure of the underlying network connection, which newConnectionError
+// wraps with Code -10 (errConnection).
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("nuodb: %s (error %d)", e.Message, e.Code)
+}
+
+func newConnectionError(err error) *Error {
+	return &Error{Code: errConnection, Message: err.Error()}
+}
+
+var (
+	errNoLastInsertID = errors.New("nuodb: statement did not generate a key, LastInsertId is not available")
+	errNoRowsAffected = errors.New("nuodb: statement did not modify rows, RowsAffected is not available")
+)
@@ -0,0 +1,90 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// sqlIsolationLevel avoids importing database/sql solely for its
+// IsolationLevel constants, which driver.TxOptions.Isolation already carries
+// as a plain int.
+type sqlIsolationLevel int
+
+const (
+	sqlLevelDefault sqlIsolationLevel = iota
+	sqlLevelReadUncommitted
+	sqlLevelReadCommitted
+	sqlLevelWriteCommitted
+	sqlLevelRepeatableRead
+	sqlLevelSnapshot
+	sqlLevelSerializable
+	sqlLevelLinearizable
+)
+
+// nuodbIsolationKeywords maps a sqlIsolationLevel onto the keyword NuoDB
+// expects after "SET TRANSACTION ISOLATION LEVEL ...". It backs both
+// isolationFromGoSQL and isolationFromDSN so a level supported via one path
+// is never silently unsupported via the other.
+var nuodbIsolationKeywords = map[sqlIsolationLevel]string{
+	sqlLevelReadCommitted:  "READ COMMITTED",
+	sqlLevelWriteCommitted: "WRITE COMMITTED",
+	sqlLevelSnapshot:       "CONSISTENT READ",
+	sqlLevelSerializable:   "SERIALIZABLE",
+}
+
+// isolationFromGoSQL maps a database/sql/driver.IsolationLevel (as set via
+// sql.TxOptions) onto the keywords NuoDB expects after
+// "SET TRANSACTION ISOLATION LEVEL ...". driver.IsolationLevel(0) is
+// sql.LevelDefault, in which case the connection's existing isolation level
+// is left untouched.
+func isolationFromGoSQL(level driver.IsolationLevel) (string, error) {
+	sqlLevel := sqlIsolationLevel(level)
+	if sqlLevel == sqlLevelDefault {
+		return "", nil
+	}
+	keyword, ok := nuodbIsolationKeywords[sqlLevel]
+	if !ok {
+		return "", fmt.Errorf("nuodb: isolation level %d is not supported", level)
+	}
+	return keyword, nil
+}
+
+// dsnIsolationLevels maps the "isolation=" DSN query parameter, following
+// the style of sqlite3's "_txlock" and libpq's
+// "default_transaction_isolation", onto the sqlIsolationLevel carrying the
+// NuoDB keyword it should use.
+var dsnIsolationLevels = map[string]sqlIsolationLevel{
+	"read_committed":  sqlLevelReadCommitted,
+	"consistent_read": sqlLevelSnapshot,
+	"write_committed": sqlLevelWriteCommitted,
+	"serializable":    sqlLevelSerializable,
+}
+
+func isolationFromDSN(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	level, ok := dsnIsolationLevels[value]
+	if !ok {
+		return "", fmt.Errorf("nuodb: unknown isolation %q in dsn", value)
+	}
+	return nuodbIsolationKeywords[level], nil
+}
+
+// isolationStatement builds the "SET TRANSACTION ..." statement to issue
+// when opening a transaction with a non-default isolation level and/or a
+// read-only access mode. It returns "" if neither was requested.
+func isolationStatement(level string, readOnly bool) string {
+	switch {
+	case level == "" && !readOnly:
+		return ""
+	case level == "" && readOnly:
+		return "SET TRANSACTION READ ONLY"
+	case readOnly:
+		return "SET TRANSACTION ISOLATION LEVEL " + level + " READ ONLY"
+	default:
+		return "SET TRANSACTION ISOLATION LEVEL " + level
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func TestIsolationFromGoSQL(t *testing.T) {
+	cases := []struct {
+		level driver.IsolationLevel
+		want  string
+	}{
+		{driver.IsolationLevel(sql.LevelDefault), ""},
+		{driver.IsolationLevel(sql.LevelReadCommitted), "READ COMMITTED"},
+		{driver.IsolationLevel(sql.LevelWriteCommitted), "WRITE COMMITTED"},
+		{driver.IsolationLevel(sql.LevelSnapshot), "CONSISTENT READ"},
+		{driver.IsolationLevel(sql.LevelSerializable), "SERIALIZABLE"},
+	}
+	for _, c := range cases {
+		got, err := isolationFromGoSQL(c.level)
+		if err != nil {
+			t.Fatalf("isolationFromGoSQL(%v): %v", c.level, err)
+		}
+		if got != c.want {
+			t.Errorf("isolationFromGoSQL(%v) = %q, want %q", c.level, got, c.want)
+		}
+	}
+
+	if _, err := isolationFromGoSQL(driver.IsolationLevel(sql.LevelLinearizable)); err == nil {
+		t.Fatal("Expected error for unsupported isolation level")
+	}
+}
+
+func TestConnectionPropsIsolation(t *testing.T) {
+	expectedIsolation := "CONSISTENT READ"
+	dsn := default_dsn + "&isolation=consistent_read"
+
+	db, err := sql.Open("nuodb", dsn)
+	if err != nil {
+		t.Fatal("sql.Open:", err)
+	}
+	defer db.Close()
+
+	rows := query(t, db, "SELECT isolationlevel FROM system.connections WHERE connid = GETCONNECTIONID()")
+	defer rows.Close()
+
+	if !rows.Next() {
+		t.Fatal("Expected rows")
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+
+	var isolation string
+	rows.Scan(&isolation)
+	isolation = strings.ToUpper(isolation)
+
+	if isolation != expectedIsolation {
+		t.Fatalf("Expected isolation '%s', was '%s'", expectedIsolation, isolation)
+	}
+}
+
+func TestConnectionPropsReadOnly(t *testing.T) {
+	dsn := default_dsn + "&readonly=true"
+
+	db, err := sql.Open("nuodb", dsn)
+	if err != nil {
+		t.Fatal("sql.Open:", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE tests.FooBarTen (id BIGINT)")
+	if err == nil {
+		t.Fatal("Expected write against a readonly=true connection to fail")
+	}
+}
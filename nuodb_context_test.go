@@ -0,0 +1,96 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// TestQueryContextCancel mirrors the style of TestCommitAndRollback: it
+// verifies that a long-running SELECT aborts promptly when the caller
+// cancels its context, rather than blocking until the statement finishes on
+// its own.
+func TestQueryContextCancel(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarSeven (id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL)")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := db.QueryContext(ctx, "SELECT SLEEP(10) FROM tests.FooBarSeven")
+		errCh <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("Expected query to fail after context cancellation")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("QueryContext did not return promptly after cancellation")
+	}
+}
+
+// TestQueryContextCancelTwice pins the pool to a single *Conn and cancels two
+// queries run on it back to back, guarding against cancel latching
+// permanently after its first use and turning every later cancellation on
+// the same connection into a no-op.
+func TestQueryContextCancelTwice(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	exec(t, db, "CREATE TABLE tests.FooBarThirteen (id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL)")
+
+	cancelOnce := func() error {
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := db.QueryContext(ctx, "SELECT SLEEP(10) FROM tests.FooBarThirteen")
+			errCh <- err
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-errCh:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("QueryContext did not return promptly after cancellation")
+			return nil
+		}
+	}
+
+	if err := cancelOnce(); err == nil {
+		t.Fatal("Expected first query to fail after context cancellation")
+	}
+	if err := cancelOnce(); err == nil {
+		t.Fatal("Expected second query on the same connection to fail after context cancellation")
+	}
+}
+
+// TestBeginTxIsolation verifies that BeginTx applies sql.TxOptions through a
+// "SET TRANSACTION ..." statement: a read-only transaction is rejected for
+// writes. The mapping of each sql.TxOptions.Isolation level onto its NuoDB
+// keyword is checked by TestIsolationFromGoSQL.
+func TestBeginTxIsolation(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarEight (id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL)")
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("INSERT INTO tests.FooBarEight DEFAULT VALUES"); err == nil {
+		t.Fatal("Expected write against a read-only transaction to fail")
+	}
+}
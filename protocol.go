@@ -0,0 +1,202 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+)
+
+func init() {
+	// driver.NamedValue.Value and Rows' cell values are interface{}; gob
+	// needs every concrete type that crosses the wire inside one of those
+	// interfaces to be registered up front.
+	gob.Register(int64(0))
+	gob.Register(float64(0))
+	gob.Register(bool(false))
+	gob.Register("")
+	gob.Register([]byte(nil))
+	gob.Register(time.Time{})
+	gob.Register(blobRef{})
+}
+
+// opcode identifies a request or response frame on the wire. The numeric
+// values come from the iota sequence below, so reordering the constants
+// changes the wire encoding.
+type opcode uint8
+
+const (
+	opHandshake opcode = iota + 1
+	opPing
+	opBegin
+	opCommit
+	opRollback
+	opClose
+	opCancel
+	opPrepare
+	opCloseStatement
+	opExecuteDirect
+	opExecuteQuery
+	opExecutePrepared
+	opExecutePreparedQuery
+	opBlobBegin
+	opBlobChunk
+	opBlobEnd
+	opBlobFetch
+
+	opResponseOK
+	opResponseError
+)
+
+// requestPayload carries the arguments of an execute-style request. Query is
+// set for the *Direct variants, StatementHandle for the prepared variants.
+type requestPayload struct {
+	Query           string
+	StatementHandle uint64
+	Args            []driver.NamedValue
+}
+
+// response is the decoded body of an opResponseOK frame. Which fields are
+// populated depends on which request produced it.
+type response struct {
+	StatementHandle uint64
+	NumInput        int
+	LastInsertID    int64
+	HasLastInsertID bool
+	RowsAffected    int64
+	HasRowsAffected bool
+	Columns         []string
+	Values          [][]driver.Value
+	BlobID          uint64
+	BlobData        []byte
+	BlobEOF         bool
+}
+
+// blobChunkPayload carries one chunk of a BLOB being streamed to the server
+// through session.uploadBlob, identified by the handle opBlobBegin handed
+// back.
+type blobChunkPayload struct {
+	BlobID uint64
+	Data   []byte
+}
+
+// blobFetchRequest asks the server for up to MaxBytes of a BLOB starting at
+// Offset, through session.fetchBlobChunk. The response carries the chunk in
+// BlobData and reports BlobEOF once Offset+len(BlobData) has reached the end
+// of the value, mirroring uploadBlob's chunking on the write side.
+type blobFetchRequest struct {
+	BlobID   uint64
+	Offset   int64
+	MaxBytes int
+}
+
+// blobRef is the driver.Value substituted, via Conn.CheckNamedValue, for an
+// io.Reader bind argument once it has been streamed to the server; it tells
+// the server which previously-uploaded BLOB to bind in its place. On the
+// read side, a BLOB column too large to inline arrives in response.Values as
+// a blobRef rather than decoded []byte, and Rows.Next binds it to the
+// session so *BlobReader can pull the value's chunks on demand instead of
+// the whole column ever being materialized in the response.
+type blobRef struct {
+	BlobID uint64
+}
+
+func (r *response) result() driver.Result {
+	return &Result{
+		lastInsertID: r.LastInsertID,
+		hasID:        r.HasLastInsertID,
+		rowsAffected: r.RowsAffected,
+		hasRows:      r.HasRowsAffected,
+	}
+}
+
+func (r *response) rows() *Rows {
+	return &Rows{columns: r.Columns, data: r.Values}
+}
+
+// writeFrame serializes a request or response as a 4-byte big-endian length
+// prefix followed by a 1-byte opcode and a gob-encoded body.
+func writeFrame(w io.Writer, op opcode, body interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := gob.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[0:4], uint32(payload.Len()+1))
+	header[4] = byte(op)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if payload.Len() > 0 {
+		if _, err := w.Write(payload.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFrame(r io.Reader) (opcode, []byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(header)
+	if n == 0 {
+		return 0, nil, fmt.Errorf("nuodb: empty frame")
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return opcode(body[0]), body[1:], nil
+}
+
+func (s *session) sendRequest(ctx context.Context, op opcode, body interface{}) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	} else {
+		s.conn.SetWriteDeadline(time.Time{})
+	}
+	if err := writeFrame(s.conn, op, body); err != nil {
+		return newConnectionError(err)
+	}
+	return nil
+}
+
+func (s *session) readResponse(ctx context.Context) (*response, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetReadDeadline(deadline)
+	} else {
+		s.conn.SetReadDeadline(time.Time{})
+	}
+	op, body, err := readFrame(s.conn)
+	if err != nil {
+		return nil, newConnectionError(err)
+	}
+	switch op {
+	case opResponseError:
+		var nerr Error
+		if err := gob.NewDecoder(bytes.NewReader(body)).Decode(&nerr); err != nil {
+			return nil, newConnectionError(err)
+		}
+		return nil, &nerr
+	case opResponseOK:
+		var resp response
+		if len(body) > 0 {
+			if err := gob.NewDecoder(bytes.NewReader(body)).Decode(&resp); err != nil {
+				return nil, newConnectionError(err)
+			}
+		}
+		return &resp, nil
+	default:
+		return nil, fmt.Errorf("nuodb: unexpected response opcode %d", op)
+	}
+}
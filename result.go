@@ -0,0 +1,27 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// Result implements database/sql/driver.Result. DDL statements and queries
+// against tables with no generated key report neither a last insert id nor
+// a row count, matching NuoDB's own semantics (see TestDDL).
+type Result struct {
+	lastInsertID int64
+	hasID        bool
+	rowsAffected int64
+	hasRows      bool
+}
+
+func (r *Result) LastInsertId() (int64, error) {
+	if !r.hasID {
+		return 0, errNoLastInsertID
+	}
+	return r.lastInsertID, nil
+}
+
+func (r *Result) RowsAffected() (int64, error) {
+	if !r.hasRows {
+		return 0, errNoRowsAffected
+	}
+	return r.rowsAffected, nil
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"io"
+)
+
+// Rows implements database/sql/driver.Rows over a result set that has
+// already been fetched in full from the Transaction Engine, except for
+// oversized BLOB columns: those arrive in data as a blobRef rather than
+// decoded bytes, and session lets Next bind them to a *blobHandle, which a
+// *BlobReader destination then uses to fetch the value's chunks on demand.
+type Rows struct {
+	columns []string
+	data    [][]driver.Value
+	pos     int
+	session *session
+}
+
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+func (r *Rows) Close() error {
+	r.pos = len(r.data)
+	return nil
+}
+
+func (r *Rows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	row := r.data[r.pos]
+	for i, v := range row {
+		if ref, ok := v.(blobRef); ok {
+			dest[i] = &blobHandle{session: r.session, blobID: ref.BlobID}
+			continue
+		}
+		dest[i] = v
+	}
+	r.pos++
+	return nil
+}
@@ -0,0 +1,323 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"io"
+	"net"
+	"sync"
+)
+
+// session owns the TCP connection to a single NuoDB Transaction Engine and
+// serializes the request/response exchanges that make up the NuoDB client
+// protocol. A session is not safe for concurrent use by multiple
+// goroutines; *Conn does not lock around session calls (its mutex only
+// guards which session is current), so callers must not overlap requests.
+// The one call made from another goroutine is cancel, from Conn.watch.
+type session struct {
+	conn net.Conn
+	cfg  *Config
+
+	mu        sync.Mutex // guards cancelled; ordinary requests do not take it
+	cancelled bool
+}
+
+// newSession dials the brokers in cfg.brokerAddrs(), in failover order, and
+// returns a handshaken session on the first one that accepts the
+// connection. Config.brokerAddrs orders that address list; newSession cuts
+// it to the first cfg.MaxRetries entries when MaxRetries is positive.
+func newSession(ctx context.Context, cfg *Config) (*session, error) {
+	addrs := cfg.brokerAddrs()
+	if cfg.MaxRetries > 0 && cfg.MaxRetries < len(addrs) {
+		addrs = addrs[:cfg.MaxRetries]
+	}
+
+	var lastErr error
+	for _, addr := range addrs {
+		s, err := newSessionAddr(ctx, cfg, addr)
+		if err == nil {
+			return s, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func newSessionAddr(ctx context.Context, cfg *Config, addr string) (*session, error) {
+	if cfg.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.DialTimeout)
+		defer cancel()
+	}
+
+	conn, err := dialAddr(ctx, cfg, addr)
+	if err != nil {
+		return nil, newConnectionError(err)
+	}
+
+	s := &session{conn: conn, cfg: cfg}
+	if err := s.handshake(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// dialAddr opens the raw network connection to addr, using cfg.DialFunc in
+// place of a plain net.Dialer when set, and wrapping the result in a TLS
+// client connection when cfg.TLSConfig is set.
+func dialAddr(ctx context.Context, cfg *Config, addr string) (net.Conn, error) {
+	dial := cfg.DialFunc
+	if dial == nil {
+		var dialer net.Dialer
+		dial = dialer.DialContext
+	}
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TLSConfig != nil {
+		conn = tls.Client(conn, cfg.TLSConfig)
+	}
+	return conn, nil
+}
+
+// handshake performs the initial protocol negotiation and authentication
+// with the Transaction Engine, then applies any connection-level properties
+// (schema, timezone, client info) carried in the Config.
+func (s *session) handshake(ctx context.Context) error {
+	if err := s.sendRequest(ctx, opHandshake, map[string]string{
+		"user":     s.cfg.User,
+		"password": s.cfg.Password,
+		"database": s.cfg.Database,
+	}); err != nil {
+		return err
+	}
+	if _, err := s.readResponse(ctx); err != nil {
+		return err
+	}
+
+	if s.cfg.Schema != "" {
+		if _, err := s.executeDirect(ctx, "USE "+s.cfg.Schema, nil); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Timezone != "" {
+		if _, err := s.executeDirect(ctx, "SET TIME ZONE '"+s.cfg.Timezone+"'", nil); err != nil {
+			return err
+		}
+	}
+	if s.cfg.ClientInfo != "" {
+		if _, err := s.executeDirect(ctx, "SET CLIENTINFO '"+s.cfg.ClientInfo+"'", nil); err != nil {
+			return err
+		}
+	}
+	for k, v := range s.cfg.Properties {
+		if _, err := s.executeDirect(ctx, "SET "+k+" '"+v+"'", nil); err != nil {
+			return err
+		}
+	}
+	if stmt := isolationStatement(s.cfg.Isolation, s.cfg.ReadOnly); stmt != "" {
+		if _, err := s.executeDirect(ctx, stmt, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancel asks the Transaction Engine to abort the statement currently
+// executing on this session, as invoked from a goroutine watching a
+// context's Done channel. It only sends opCancel once per call that
+// resetCancel armed, so a watchdog racing its own stop() can't send it
+// twice.
+func (s *session) cancel() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancelled {
+		return nil
+	}
+	s.cancelled = true
+	return s.sendRequest(context.Background(), opCancel, nil)
+}
+
+// resetCancel re-arms cancel for a new call on this session. A session
+// outlives any single exec/query/prepare/begin/Ping — it's only replaced on
+// reconnect — so without this, cancelling one context-bound call would
+// latch cancelled permanently and turn every later cancellation on the same
+// connection into a silent no-op.
+func (s *session) resetCancel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelled = false
+}
+
+func (s *session) ping(ctx context.Context) error {
+	if err := s.sendRequest(ctx, opPing, nil); err != nil {
+		return err
+	}
+	_, err := s.readResponse(ctx)
+	return err
+}
+
+func (s *session) begin(ctx context.Context) error {
+	if err := s.sendRequest(ctx, opBegin, nil); err != nil {
+		return err
+	}
+	_, err := s.readResponse(ctx)
+	return err
+}
+
+func (s *session) commit(ctx context.Context) error {
+	if err := s.sendRequest(ctx, opCommit, nil); err != nil {
+		return err
+	}
+	_, err := s.readResponse(ctx)
+	return err
+}
+
+func (s *session) rollback(ctx context.Context) error {
+	if err := s.sendRequest(ctx, opRollback, nil); err != nil {
+		return err
+	}
+	_, err := s.readResponse(ctx)
+	return err
+}
+
+func (s *session) close() error {
+	_ = s.sendRequest(context.Background(), opClose, nil)
+	return s.conn.Close()
+}
+
+// blobChunkSize bounds how many bytes of an uploaded BLOB are held in
+// memory at once by uploadBlob.
+const blobChunkSize = 64 * 1024
+
+// uploadBlob streams r to the server in fixed-size chunks and returns a
+// blobRef identifying the upload, so a bind argument that arrived as an
+// io.Reader never has to be buffered into a single []byte.
+func (s *session) uploadBlob(ctx context.Context, r io.Reader) (blobRef, error) {
+	if err := s.sendRequest(ctx, opBlobBegin, nil); err != nil {
+		return blobRef{}, err
+	}
+	resp, err := s.readResponse(ctx)
+	if err != nil {
+		return blobRef{}, err
+	}
+	id := resp.BlobID
+
+	buf := make([]byte, blobChunkSize)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			chunk := blobChunkPayload{BlobID: id, Data: append([]byte(nil), buf[:n]...)}
+			if err := s.sendRequest(ctx, opBlobChunk, chunk); err != nil {
+				return blobRef{}, err
+			}
+			if _, err := s.readResponse(ctx); err != nil {
+				return blobRef{}, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return blobRef{}, readErr
+		}
+	}
+
+	if err := s.sendRequest(ctx, opBlobEnd, blobRef{BlobID: id}); err != nil {
+		return blobRef{}, err
+	}
+	if _, err := s.readResponse(ctx); err != nil {
+		return blobRef{}, err
+	}
+	return blobRef{BlobID: id}, nil
+}
+
+// fetchBlobChunk requests up to maxBytes of the BLOB identified by id,
+// starting at offset, so a *BlobReader returned for an oversized BLOB column
+// (see Rows.Next) can pull the value from the result stream incrementally
+// instead of requiring it to have been decoded into response.Values in full.
+func (s *session) fetchBlobChunk(ctx context.Context, id uint64, offset int64, maxBytes int) (data []byte, eof bool, err error) {
+	if err := s.sendRequest(ctx, opBlobFetch, blobFetchRequest{BlobID: id, Offset: offset, MaxBytes: maxBytes}); err != nil {
+		return nil, false, err
+	}
+	resp, err := s.readResponse(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.BlobData, resp.BlobEOF, nil
+}
+
+// prepare sends a statement to the server to be parsed and bound once, and
+// returns an opaque handle used by subsequent executePrepared/queryPrepared
+// calls along with the number of bind placeholders it found.
+func (s *session) prepare(ctx context.Context, query string) (handle uint64, numInput int, err error) {
+	if err := s.sendRequest(ctx, opPrepare, query); err != nil {
+		return 0, 0, err
+	}
+	resp, err := s.readResponse(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return resp.StatementHandle, resp.NumInput, nil
+}
+
+func (s *session) closeStatement(ctx context.Context, handle uint64) error {
+	if err := s.sendRequest(ctx, opCloseStatement, handle); err != nil {
+		return err
+	}
+	_, err := s.readResponse(ctx)
+	return err
+}
+
+func (s *session) executeDirect(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.sendRequest(ctx, opExecuteDirect, requestPayload{Query: query, Args: args}); err != nil {
+		return nil, err
+	}
+	resp, err := s.readResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.result(), nil
+}
+
+func (s *session) queryDirect(ctx context.Context, query string, args []driver.NamedValue) (*Rows, error) {
+	if err := s.sendRequest(ctx, opExecuteQuery, requestPayload{Query: query, Args: args}); err != nil {
+		return nil, err
+	}
+	resp, err := s.readResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows := resp.rows()
+	rows.session = s
+	return rows, nil
+}
+
+func (s *session) executePrepared(ctx context.Context, handle uint64, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.sendRequest(ctx, opExecutePrepared, requestPayload{StatementHandle: handle, Args: args}); err != nil {
+		return nil, err
+	}
+	resp, err := s.readResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.result(), nil
+}
+
+func (s *session) queryPrepared(ctx context.Context, handle uint64, args []driver.NamedValue) (*Rows, error) {
+	if err := s.sendRequest(ctx, opExecutePreparedQuery, requestPayload{StatementHandle: handle, Args: args}); err != nil {
+		return nil, err
+	}
+	resp, err := s.readResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows := resp.rows()
+	rows.session = s
+	return rows, nil
+}
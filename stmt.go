@@ -0,0 +1,69 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// Stmt implements database/sql/driver.Stmt plus driver.StmtExecContext and
+// driver.StmtQueryContext, so a context passed to sql.Stmt.ExecContext or
+// QueryContext cancels the in-flight call on the owning connection.
+type Stmt struct {
+	conn     *Conn
+	handle   uint64
+	numInput int
+}
+
+func (s *Stmt) Close() error {
+	s.conn.forgetStmt(s)
+	return s.conn.getSession().closeStatement(context.Background(), s.handle)
+}
+
+func (s *Stmt) NumInput() int {
+	return s.numInput
+}
+
+func (s *Stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.exec(context.Background(), namedValuesFromValues(args))
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *Stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.exec(ctx, args)
+}
+
+func (s *Stmt) exec(ctx context.Context, args []driver.NamedValue) (result driver.Result, err error) {
+	stop := s.conn.watch(ctx)
+	defer stop()
+	err = s.conn.withReconnect(ctx, func() error {
+		var err error
+		result, err = s.conn.getSession().executePrepared(ctx, s.handle, args)
+		return err
+	})
+	return result, err
+}
+
+func (s *Stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.query(context.Background(), namedValuesFromValues(args))
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.query(ctx, args)
+}
+
+func (s *Stmt) query(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	stop := s.conn.watch(ctx)
+	defer stop()
+	err = s.conn.withReconnect(ctx, func() error {
+		r, err := s.conn.getSession().queryPrepared(ctx, s.handle, args)
+		if err != nil {
+			return err
+		}
+		rows = r
+		return nil
+	})
+	return rows, err
+}
@@ -0,0 +1,20 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import "context"
+
+// Tx implements database/sql/driver.Tx.
+type Tx struct {
+	conn *Conn
+}
+
+func (tx *Tx) Commit() error {
+	defer tx.conn.endTx()
+	return tx.conn.session.commit(context.Background())
+}
+
+func (tx *Tx) Rollback() error {
+	defer tx.conn.endTx()
+	return tx.conn.session.rollback(context.Background())
+}
@@ -0,0 +1,19 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import "database/sql/driver"
+
+// namedValuesFromValues adapts the legacy driver.Execer/driver.Queryer
+// argument slice to driver.NamedValue so that the *Context variants can
+// share a single code path with the older, non-context methods.
+func namedValuesFromValues(args []driver.Value) []driver.NamedValue {
+	if args == nil {
+		return nil
+	}
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}